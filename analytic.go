@@ -0,0 +1,70 @@
+package main
+
+import "math/big"
+
+// HypergeometricRow is the exact win probability by a given turn, for
+// the simple "draw D cards from an N-card deck with C combo pieces,
+// need R" question the legacy (non-decklist, non-mulligan) flat Config
+// answers by simulation.
+type HypergeometricRow struct {
+	Turn    int
+	Draws   int
+	ProbWin float64
+}
+
+// AnalyticWinProbabilities computes the exact hypergeometric table of
+// P(win by turn T), for T from 0 (the opening hand) through deck-out,
+// using the closed-form hypergeometric tail:
+//
+//	P(X >= R after D draws) = 1 - Σ_{i=0}^{R-1} C(C,i)·C(N-C, D-i) / C(N, D)
+//
+// It is an exact oracle for the same question runScenario samples, and
+// ignores decklists, named combo groups, and mulligans - it models only
+// cfg.deckSize/combos/required and cfg.rules.startingHandSize.
+func AnalyticWinProbabilities(cfg Config) []HypergeometricRow {
+	rules := effectiveRules(cfg.rules)
+	n, c, r := int64(cfg.deckSize), int64(cfg.combos), int64(cfg.required)
+
+	maxTurn := cfg.deckSize - rules.startingHandSize
+	rows := make([]HypergeometricRow, 0, maxTurn+1)
+	for turn := 0; turn <= maxTurn; turn++ {
+		draws := rules.startingHandSize + turn
+		rows = append(rows, HypergeometricRow{
+			Turn:    turn,
+			Draws:   draws,
+			ProbWin: hypergeometricAtLeast(n, c, r, int64(draws)),
+		})
+	}
+	return rows
+}
+
+// hypergeometricAtLeast returns P(X >= r) for X ~ Hypergeometric(N, C,
+// D): drawing D cards without replacement from an N-card deck
+// containing C successes. Binomial coefficients are computed exactly
+// with math/big.Int so the result holds for commander-sized decks, then
+// converted to a big.Rat ratio and finally to float64.
+func hypergeometricAtLeast(n, c, r, d int64) float64 {
+	total := safeBinomial(n, d)
+	if total.Sign() == 0 {
+		return 0
+	}
+
+	failure := new(big.Rat)
+	for i := int64(0); i < r; i++ {
+		ways := new(big.Int).Mul(safeBinomial(c, i), safeBinomial(n-c, d-i))
+		failure.Add(failure, new(big.Rat).SetFrac(ways, total))
+	}
+
+	prob := new(big.Rat).Sub(big.NewRat(1, 1), failure)
+	f, _ := prob.Float64()
+	return f
+}
+
+// safeBinomial returns C(n, k), or 0 for the out-of-domain k < 0 or
+// k > n that big.Int.Binomial doesn't reliably zero itself.
+func safeBinomial(n, k int64) *big.Int {
+	if n < 0 || k < 0 || k > n {
+		return new(big.Int)
+	}
+	return new(big.Int).Binomial(n, k)
+}
@@ -0,0 +1,68 @@
+// Package deck parses text decklists into typed cards so mindcrank can
+// model a real archetype instead of an anonymous lands/non-lands split.
+package deck
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Card is a single card entry in a parsed decklist, carrying enough
+// detail for the simulator to tell combo pieces, tutors, and ramp apart
+// instead of treating every non-land as an interchangeable slot.
+type Card struct {
+	Name     string
+	ManaCost int
+	Types    []string
+	Tags     []string
+}
+
+// ComboGroup returns the combo group this card belongs to, from a
+// "combo-piece:<group>" tag, or "" if it isn't a combo piece.
+func (c Card) ComboGroup() string {
+	return tagValue(c.Tags, "combo-piece")
+}
+
+// TutorGroup returns the combo group this card searches for, from a
+// "tutor:<group>" tag, or "" if it isn't a tutor.
+func (c Card) TutorGroup() string {
+	return tagValue(c.Tags, "tutor")
+}
+
+// IsRamp reports whether this card is tagged "ramp".
+func (c Card) IsRamp() bool {
+	return hasTag(c.Tags, "ramp")
+}
+
+// DrawCount returns the number of extra cards this card draws, from a
+// "draw:<n>" tag, or 0 if it isn't a draw spell.
+func (c Card) DrawCount() int {
+	v := tagValue(c.Tags, "draw")
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func tagValue(tags []string, key string) string {
+	prefix := key + ":"
+	for _, t := range tags {
+		if strings.HasPrefix(t, prefix) {
+			return strings.TrimPrefix(t, prefix)
+		}
+	}
+	return ""
+}
+
+func hasTag(tags []string, key string) bool {
+	for _, t := range tags {
+		if t == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package deck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExpandsCountsAndTags(t *testing.T) {
+	list := `// a tiny combo deck
+4 Dark Ritual {B} ramp
+1 Demonic Tutor {B} tutor:combo
+1 Tainted Pact {1}{U} combo-piece:A
+1 Lion's Eye Diamond combo-piece:B
+17 Island {U} type:Land
+`
+	cards, err := Parse(strings.NewReader(list))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	const wantTotal = 4 + 1 + 1 + 1 + 17
+	if len(cards) != wantTotal {
+		t.Fatalf("card count mismatch: got=%d want=%d", len(cards), wantTotal)
+	}
+
+	ritual := cards[0]
+	if ritual.Name != "Dark Ritual" || ritual.ManaCost != 1 || !ritual.IsRamp() {
+		t.Fatalf("Dark Ritual parsed wrong: %+v", ritual)
+	}
+
+	tutor := cards[4]
+	if tutor.Name != "Demonic Tutor" || tutor.TutorGroup() != "combo" {
+		t.Fatalf("Demonic Tutor parsed wrong: %+v", tutor)
+	}
+
+	pact := cards[5]
+	if pact.ManaCost != 2 || pact.ComboGroup() != "A" {
+		t.Fatalf("Tainted Pact parsed wrong: %+v", pact)
+	}
+
+	lied := cards[6]
+	if lied.ComboGroup() != "B" || lied.ManaCost != 0 {
+		t.Fatalf("Lion's Eye Diamond parsed wrong: %+v", lied)
+	}
+
+	island := cards[7]
+	if len(island.Types) != 1 || island.Types[0] != "Land" {
+		t.Fatalf("Island parsed wrong: %+v", island)
+	}
+}
+
+func TestParseRejectsMalformedLine(t *testing.T) {
+	if _, err := Parse(strings.NewReader("Dark Ritual {B}")); err == nil {
+		t.Fatal("expected error for missing count")
+	}
+}
+
+func TestParseManaCostSumsGenericAndPips(t *testing.T) {
+	got, err := parseManaCost("{2}{U}{U}")
+	if err != nil {
+		t.Fatalf("parseManaCost returned error: %v", err)
+	}
+	if got != 4 {
+		t.Fatalf("mana cost mismatch: got=%d want=4", got)
+	}
+}
@@ -0,0 +1,132 @@
+package deck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parse reads a text decklist and returns the expanded list of cards -
+// one entry per physical copy, in list order. Each line has the form:
+//
+//	<count> <name> {<mana cost>} [tag ...]
+//
+// e.g. "4 Dark Ritual {B} ramp" or "1 Demonic Tutor {B} tutor:combo-a",
+// matching the usual MTGO/Arena export format with trailing tags for
+// combo-piece:<group>, tutor:<group>, ramp, draw:<n>, and type:<type>.
+// The mana cost is optional (lands have none). Blank lines and lines
+// starting with "//" are ignored.
+func Parse(r io.Reader) ([]Card, error) {
+	var cards []Card
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		card, count, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < count; i++ {
+			cards = append(cards, card)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+// parseLine parses a single non-blank decklist line into a Card and its
+// copy count.
+func parseLine(line string) (Card, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return Card{}, 0, fmt.Errorf("malformed decklist line %q", line)
+	}
+
+	count, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Card{}, 0, fmt.Errorf("malformed count in line %q: %w", line, err)
+	}
+
+	rest := fields[1:]
+	manaCostIdx := -1
+	for i, f := range rest {
+		if strings.HasPrefix(f, "{") {
+			manaCostIdx = i
+			break
+		}
+	}
+
+	var nameEnd, tagStart int
+	manaCost := 0
+	if manaCostIdx >= 0 {
+		cost, err := parseManaCost(rest[manaCostIdx])
+		if err != nil {
+			return Card{}, 0, fmt.Errorf("malformed mana cost in line %q: %w", line, err)
+		}
+		manaCost = cost
+		nameEnd, tagStart = manaCostIdx, manaCostIdx+1
+	} else {
+		// no mana cost: tags are trailing fields that look like tags
+		// (containing ":" or a known bare keyword), so a multi-word
+		// name like "Lion's Eye Diamond" isn't mistaken for one.
+		nameEnd = len(rest)
+		for nameEnd > 1 && isTagField(rest[nameEnd-1]) {
+			nameEnd--
+		}
+		tagStart = nameEnd
+	}
+
+	var types, tags []string
+	for _, t := range rest[tagStart:] {
+		if v := strings.TrimPrefix(t, "type:"); v != t {
+			types = append(types, v)
+			continue
+		}
+		tags = append(tags, t)
+	}
+
+	card := Card{
+		Name:     strings.Join(rest[:nameEnd], " "),
+		ManaCost: manaCost,
+		Types:    types,
+		Tags:     tags,
+	}
+	return card, count, nil
+}
+
+// isTagField reports whether a field looks like a decklist tag rather
+// than part of a card name: either "key:value" or a known bare keyword.
+func isTagField(f string) bool {
+	return strings.Contains(f, ":") || f == "ramp"
+}
+
+// parseManaCost converts a brace-delimited mana cost like "{2}{U}{U}"
+// into a converted mana cost: each numeric group contributes its value
+// and each symbol group (a color or generic pip) contributes 1.
+func parseManaCost(s string) (int, error) {
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return 0, fmt.Errorf("mana cost %q must be wrapped in braces", s)
+	}
+
+	total := 0
+	for _, group := range strings.Split(strings.Trim(s, "{}"), "}{") {
+		if group == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(group); err == nil {
+			total += n
+			continue
+		}
+		total++
+	}
+	return total, nil
+}
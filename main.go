@@ -3,43 +3,143 @@ package main
 // This is a Monte Carlo simulation for how fast a 2 card combo can be
 // drawn into in Magic: The Gathering. It simplifies the game down to
 // just lands and non-lands, with non-lands being the only cards capable
-// of being combo pieces. This simulation assumes 2 combo cards in hand
-// is a win-con and doesn't attempt to discern if the combo was castable.
+// of being combo pieces. The simulation plays the deck out turn by turn
+// (drawing a card and dropping a land each turn) and tracks both the
+// naive draw-based win, where the required combo pieces are simply in
+// hand, and the castable win, where there are also enough untapped
+// lands in play to pay the combo's combined mana cost.
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
+	"math"
+	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/dylanlott/mindcrank/deck"
 )
 
 // Card holds the information for a card in the game
 type Card struct {
-	keyword string // denotes land or non-land
-	combo   bool   // denotes a combo piece
+	keyword    string // denotes land or non-land
+	comboGroup string // non-empty names the combo group this card belongs to
+	tutorGroup string // non-empty names the combo group this card searches for
+	ramp       bool   // nets (1 - manaCost) bonus mana when cast, then leaves hand
+	drawCount  int    // extra cards drawn when it resolves
+	manaCost   int    // converted mana cost; lands and non-combo cards are usually 0
+}
+
+// Rules governs the starting hand size and London-mulligan behavior
+// applied before a simulation's turn-by-turn loop begins. A zero-value
+// Rules defaults to a 7-card hand with no mulligans, same as the
+// original sim.
+type Rules struct {
+	startingHandSize  int // cards drawn for an opening hand, before mulligans
+	maxMulligans      int // maximum number of mulligans a player will take
+	bottomPerMulligan int // cards put on the bottom of the library per mulligan taken
+}
+
+// Estimate is a point estimate with a 95% confidence interval: Center
+// is the CI's midpoint (equal to Value for a CLT mean, but not for a
+// Wilson-scored proportion, whose center is pulled slightly toward 0.5)
+// and HalfWidth is the CI's half-width, so the interval is
+// [Center-HalfWidth, Center+HalfWidth].
+type Estimate struct {
+	Value     float64
+	Center    float64
+	HalfWidth float64
 }
 
 // Results collates the simulations of a scenario run
 type Results struct {
-	attempts               int64
-	averageDrawsToWin      float64
-	openingHandWins        int64
-	averageOpeningHandWins float64
-	averageOpeningLands    float64
+	attempts                  int64
+	averageDrawsToWin         float64
+	drawsToWinCI              Estimate
+	openingHandWins           int64
+	averageOpeningHandWins    float64
+	openingHandWinCI          Estimate
+	averageOpeningLands       float64
+	averageMulligans          float64
+	castableWins              int64
+	averageCastableWinRate    float64
+	castableWinCI             Estimate
+	averageTurnsToCastableWin float64
+}
+
+// MarshalJSON renders Results with stable, snake_case keys so sweep
+// output (see WriteSweepJSON) and ad-hoc `go run . -mode=sweep --format
+// json` piping stay usable by downstream tools like pandas or R.
+func (r Results) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Attempts                  int64    `json:"attempts"`
+		AverageDrawsToWin         float64  `json:"average_draws_to_win"`
+		DrawsToWinCI              Estimate `json:"draws_to_win_ci"`
+		OpeningHandWins           int64    `json:"opening_hand_wins"`
+		AverageOpeningHandWins    float64  `json:"average_opening_hand_win_rate"`
+		OpeningHandWinCI          Estimate `json:"opening_hand_win_ci"`
+		AverageOpeningLands       float64  `json:"average_opening_lands"`
+		AverageMulligans          float64  `json:"average_mulligans"`
+		CastableWins              int64    `json:"castable_wins"`
+		AverageCastableWinRate    float64  `json:"average_castable_win_rate"`
+		CastableWinCI             Estimate `json:"castable_win_ci"`
+		AverageTurnsToCastableWin float64  `json:"average_turns_to_castable_win"`
+	}{
+		Attempts:                  r.attempts,
+		AverageDrawsToWin:         r.averageDrawsToWin,
+		DrawsToWinCI:              r.drawsToWinCI,
+		OpeningHandWins:           r.openingHandWins,
+		AverageOpeningHandWins:    r.averageOpeningHandWins,
+		OpeningHandWinCI:          r.openingHandWinCI,
+		AverageOpeningLands:       r.averageOpeningLands,
+		AverageMulligans:          r.averageMulligans,
+		CastableWins:              r.castableWins,
+		AverageCastableWinRate:    r.averageCastableWinRate,
+		CastableWinCI:             r.castableWinCI,
+		AverageTurnsToCastableWin: r.averageTurnsToCastableWin,
+	})
 }
 
 // Config holds the configuration for a simulation run.
 type Config struct {
-	deckSize int
-	lands    int
-	combos   int
-	required int
-	runs     int
-	seed     int64
+	deckSize      int
+	lands         int
+	combos        int
+	required      int
+	runs          int
+	seed          int64
+	rules         Rules
+	comboManaCost int // converted mana cost of each combo piece
+
+	// decklist, if set, overrides deckSize/lands/combos/comboManaCost:
+	// createDeck builds the deck directly from these parsed cards.
+	decklist []deck.Card
+	// comboGroups, if set, overrides required: it maps a combo group
+	// name to the number of pieces from that group needed to win, e.g.
+	// {"A": 1, "B": 1} for "one piece from group A and one from B".
+	comboGroups map[string]int
+
+	// adaptive, if true, runs batches of batchSize simulations (instead
+	// of cfg.runs all at once) until the opening-hand win rate's CI
+	// half-width is at most epsilon, or cfg.runs simulations have run.
+	adaptive  bool
+	epsilon   float64
+	batchSize int
+
+	// mode selects monte-carlo simulation (the default) or the exact
+	// analytic hypergeometric oracle; see AnalyticWinProbabilities.
+	mode string
+
+	// rngKind selects the Source implementation simBatch seeds per
+	// simulation: "pcg" (the default, and the empty string) or
+	// "math-rand". See newSource.
+	rngKind string
 }
 
 // Simulation holds the results of the sim's run
@@ -53,6 +153,21 @@ type Simulation struct {
 	// openingHandLands records the number of lands drawn in the
 	// opening hand
 	openingHandLands int
+	// mulligansTaken records how many London mulligans were taken
+	// before the hand was kept
+	mulligansTaken int
+	// castableWin is true if the required combo pieces were drawn
+	// into hand *and* there were enough untapped lands in play to
+	// pay their combined mana cost
+	castableWin bool
+	// turnsToCastableWin is the turn the combo became castable (0
+	// meaning the opening hand); it is -1 if the combo was never
+	// castable during the simulation
+	turnsToCastableWin int64
+	// simIndex identifies this simulation's position within its batch,
+	// so the aggregator can fold batches in a fixed order regardless of
+	// which worker happens to finish first
+	simIndex int
 }
 
 // this first scenario models a 37 land deck with 62 permanents and
@@ -66,6 +181,23 @@ func main() {
 	requiredFlag := flag.Int("required", 2, "number of combo pieces required for a win")
 	runsFlag := flag.Int("runs", 10_000_000, "number of simulations to run")
 	seedFlag := flag.Int64("seed", 0, "random seed (0 uses current time)")
+	startingHandFlag := flag.Int("starting-hand", 7, "opening hand size, before mulligans")
+	maxMulligansFlag := flag.Int("max-mulligans", 0, "maximum number of London mulligans taken")
+	bottomPerMulliganFlag := flag.Int("bottom-per-mulligan", 1, "cards put on the bottom of the library per mulligan taken")
+	comboManaCostFlag := flag.Int("combo-mana-cost", 2, "converted mana cost of each combo piece")
+	decklistFlag := flag.String("decklist", "", "path to a text decklist; overrides deck-size/lands/combos/required")
+	adaptiveFlag := flag.Bool("adaptive", false, "run batches until the opening-hand win rate CI half-width is below --epsilon, up to --runs simulations")
+	epsilonFlag := flag.Float64("epsilon", 0.005, "target CI half-width for --adaptive mode")
+	batchSizeFlag := flag.Int("batch-size", 100_000, "simulations per --adaptive batch")
+	modeFlag := flag.String("mode", "monte-carlo", "simulation mode: monte-carlo, analytic, or sweep")
+	rngFlag := flag.String("rng", "pcg", "random source for shuffles/draws: pcg or math-rand")
+	sweepLandsFlag := flag.String("sweep-lands", "", "lands range to sweep, as start:end[:step] (--mode=sweep only)")
+	sweepCombosFlag := flag.String("sweep-combos", "", "combos range to sweep, as start:end[:step] (--mode=sweep only)")
+	sweepRequiredFlag := flag.String("sweep-required", "", "required range to sweep, as start:end[:step] (--mode=sweep only)")
+	sweepMaxMulligansFlag := flag.String("sweep-max-mulligans", "", "max-mulligans range to sweep, as start:end[:step] (--mode=sweep only)")
+	formatFlag := flag.String("format", "csv", "sweep output format: csv or json (--mode=sweep only)")
+	pivotFlag := flag.String("pivot", "wide", "sweep CSV layout: wide (one row per point) or long (one row per point/metric) (--mode=sweep only)")
+	outputFlag := flag.String("output", "", "sweep output file path; empty writes to stdout (--mode=sweep only)")
 	flag.Parse()
 
 	seed := *seedFlag
@@ -73,12 +205,32 @@ func main() {
 		seed = time.Now().UnixNano()
 	}
 	cfg := Config{
-		deckSize: *deckSizeFlag,
-		lands:    *landsFlag,
-		combos:   *combosFlag,
-		required: *requiredFlag,
-		runs:     *runsFlag,
-		seed:     seed,
+		deckSize:      *deckSizeFlag,
+		lands:         *landsFlag,
+		combos:        *combosFlag,
+		required:      *requiredFlag,
+		runs:          *runsFlag,
+		seed:          seed,
+		comboManaCost: *comboManaCostFlag,
+		rules: Rules{
+			startingHandSize:  *startingHandFlag,
+			maxMulligans:      *maxMulligansFlag,
+			bottomPerMulligan: *bottomPerMulliganFlag,
+		},
+		adaptive:  *adaptiveFlag,
+		epsilon:   *epsilonFlag,
+		batchSize: *batchSizeFlag,
+		mode:      *modeFlag,
+		rngKind:   *rngFlag,
+	}
+
+	if *decklistFlag != "" {
+		cards, groups, err := loadDecklist(*decklistFlag)
+		if err != nil {
+			log.Fatalf("invalid decklist: %v", err)
+		}
+		cfg.decklist = cards
+		cfg.comboGroups = groups
 	}
 
 	if err := validateConfig(cfg); err != nil {
@@ -87,7 +239,66 @@ func main() {
 
 	fmt.Printf("🎲 RNG seed: %d\n", cfg.seed)
 
-	results, err := runScenario(cfg)
+	if cfg.mode == "analytic" {
+		fmt.Printf("📐 analytic hypergeometric win probabilities:\n")
+		for _, row := range AnalyticWinProbabilities(cfg) {
+			fmt.Printf("turn %d (draws=%d): P(win) = %.4f\n", row.Turn, row.Draws, row.ProbWin)
+		}
+		return
+	}
+
+	if cfg.mode == "sweep" {
+		landsRange, err := parseRange(*sweepLandsFlag)
+		if err != nil {
+			log.Fatalf("invalid config: %v", err)
+		}
+		combosRange, err := parseRange(*sweepCombosFlag)
+		if err != nil {
+			log.Fatalf("invalid config: %v", err)
+		}
+		requiredRange, err := parseRange(*sweepRequiredFlag)
+		if err != nil {
+			log.Fatalf("invalid config: %v", err)
+		}
+		maxMulligansRange, err := parseRange(*sweepMaxMulligansFlag)
+		if err != nil {
+			log.Fatalf("invalid config: %v", err)
+		}
+
+		points, err := RunSweep(cfg, landsRange, combosRange, requiredRange, maxMulligansRange)
+		if err != nil {
+			log.Fatalf("error: %+v", err)
+		}
+
+		out := io.Writer(os.Stdout)
+		if *outputFlag != "" {
+			f, err := os.Create(*outputFlag)
+			if err != nil {
+				log.Fatalf("error: %+v", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		switch *formatFlag {
+		case "json":
+			err = WriteSweepJSON(out, points)
+		default:
+			err = WriteSweepCSV(out, points, *pivotFlag)
+		}
+		if err != nil {
+			log.Fatalf("error: %+v", err)
+		}
+
+		fmt.Printf("📈 wrote %d sweep points\n", len(points))
+		return
+	}
+
+	runScenarioFn := runScenario
+	if cfg.adaptive {
+		runScenarioFn = runAdaptiveScenario
+	}
+	results, err := runScenarioFn(cfg)
 	if err != nil {
 		log.Fatalf("error: %+v", err)
 	}
@@ -95,10 +306,45 @@ func main() {
 	fmt.Printf("📊 results:\n%+v\n", results)
 }
 
-// runScenario runs a deck simulations a given number of times.
+// runScenario runs a deck simulation a given number of times.
 func runScenario(cfg Config) (Results, error) {
-	var results = Results{}
+	agg := &aggregator{}
+	agg.addBatch(simBatch(cfg, cfg.runs, 0))
+	return agg.results(), nil
+}
 
+// runAdaptiveScenario runs batches of cfg.batchSize simulations,
+// checking the opening-hand win rate's CI half-width after each one,
+// until it drops to or below cfg.epsilon or cfg.runs simulations (the
+// adaptive mode's max-runs cap) have run. Because the aggregator folds
+// in each batch incrementally, no work is discarded between batches.
+func runAdaptiveScenario(cfg Config) (Results, error) {
+	agg := &aggregator{}
+	simIndex := 0
+	for simIndex < cfg.runs {
+		n := cfg.batchSize
+		if simIndex+n > cfg.runs {
+			n = cfg.runs - simIndex
+		}
+
+		agg.addBatch(simBatch(cfg, n, simIndex))
+		simIndex += n
+
+		if agg.attempts > 0 {
+			results := agg.results()
+			if results.openingHandWinCI.HalfWidth <= cfg.epsilon {
+				break
+			}
+		}
+	}
+	return agg.results(), nil
+}
+
+// simBatch launches a worker pool that runs n simulations, with sim
+// indices offset by simIndexBase so repeated batches draw distinct RNG
+// streams, and streams each Simulation back over the returned channel,
+// closing it once the batch completes.
+func simBatch(cfg Config, n int, simIndexBase int) <-chan Simulation {
 	workerCount := runtime.NumCPU()
 	jobs := make(chan int, workerCount)
 	output := make(chan Simulation, 10_000)
@@ -109,48 +355,165 @@ func runScenario(cfg Config) (Results, error) {
 		go func() {
 			defer workers.Done()
 			for simIndex := range jobs {
-				rng := rand.New(rand.NewSource(simSeed(cfg.seed, simIndex)))
-				deck := createDeck(cfg, rng)
-				output <- runSimulation(deck, cfg.required)
+				src := newSource(cfg.rngKind, simSeed(cfg.seed, simIndex))
+				cards := createDeck(cfg, src)
+				sim := runSimulation(cards, src, cfg)
+				sim.simIndex = simIndex
+				output <- sim
 			}
 		}()
 	}
 
 	go func() {
-		for i := 0; i < cfg.runs; i++ {
-			jobs <- i
+		for i := 0; i < n; i++ {
+			jobs <- simIndexBase + i
 		}
 		close(jobs)
 		workers.Wait()
 		close(output)
 	}()
 
-	var drawSum int64
-	var landSum int64
-	var openingWinCount int64
+	return output
+}
+
+// aggregator folds simulation outcomes into running sums incrementally,
+// using Welford's online algorithm for the draws-to-win mean/variance,
+// so batches can be combined across runScenario/runAdaptiveScenario
+// without re-processing earlier results. addBatch folds simulations in
+// fixed simIndex order rather than arrival order, so the floating-point
+// accumulation - and therefore the result - doesn't depend on which
+// worker happens to finish first; pending holds only the handful of
+// simulations that arrived ahead of the next expected index, not the
+// whole batch.
+type aggregator struct {
+	attempts         int64
+	drawMean         float64
+	drawM2           float64
+	landSum          int64
+	openingWinCount  int64
+	mulliganSum      int64
+	castableWinCount int64
+	castableTurnSum  int64
+
+	nextIndex int
+	pending   map[int]Simulation
+}
 
-	for sim := range output {
-		results.attempts++
-		if sim.openingHandWin {
-			openingWinCount++
+// addBatch folds each of a batch's simulations into the running
+// aggregates in simIndex order as they arrive from the channel,
+// reordering only the small number of simulations that outrun the next
+// expected index - never the whole batch.
+func (a *aggregator) addBatch(batch <-chan Simulation) {
+	if a.pending == nil {
+		a.pending = make(map[int]Simulation)
+	}
+	for sim := range batch {
+		a.pending[sim.simIndex] = sim
+		for {
+			next, ok := a.pending[a.nextIndex]
+			if !ok {
+				break
+			}
+			delete(a.pending, a.nextIndex)
+			a.add(next)
+			a.nextIndex++
 		}
-		drawSum += sim.drawsToWinCon
-		landSum += int64(sim.openingHandLands)
+	}
+}
+
+func (a *aggregator) add(sim Simulation) {
+	a.attempts++
+
+	x := float64(sim.drawsToWinCon)
+	delta := x - a.drawMean
+	a.drawMean += delta / float64(a.attempts)
+	a.drawM2 += delta * (x - a.drawMean)
+
+	a.landSum += int64(sim.openingHandLands)
+	a.mulliganSum += int64(sim.mulligansTaken)
+	if sim.openingHandWin {
+		a.openingWinCount++
+	}
+	if sim.castableWin {
+		a.castableWinCount++
+		a.castableTurnSum += sim.turnsToCastableWin
+	}
+}
+
+// results computes the point estimates and 95% confidence intervals for
+// the accumulated attempts: a CLT interval (sample std dev / sqrt(n))
+// for the draws-to-win mean, and Wilson score intervals for the
+// opening-hand and castable win rates.
+func (a *aggregator) results() Results {
+	var results Results
+	if a.attempts == 0 {
+		return results
 	}
 
-	if results.attempts > 0 {
-		results.averageDrawsToWin = float64(drawSum) / float64(results.attempts)
-		results.averageOpeningHandWins = float64(openingWinCount) / float64(results.attempts)
-		results.openingHandWins = openingWinCount
-		results.averageOpeningLands = float64(landSum) / float64(results.attempts)
+	n := a.attempts
+	results.attempts = n
+	results.averageDrawsToWin = a.drawMean
+	results.averageOpeningHandWins = float64(a.openingWinCount) / float64(n)
+	results.openingHandWins = a.openingWinCount
+	results.averageOpeningLands = float64(a.landSum) / float64(n)
+	results.averageMulligans = float64(a.mulliganSum) / float64(n)
+	results.castableWins = a.castableWinCount
+	results.averageCastableWinRate = float64(a.castableWinCount) / float64(n)
+
+	drawStdDev := 0.0
+	if n > 1 {
+		drawStdDev = math.Sqrt(a.drawM2 / float64(n-1))
 	}
+	results.drawsToWinCI = cltEstimate(a.drawMean, drawStdDev, n)
+	results.openingHandWinCI = wilsonEstimate(a.openingWinCount, n)
 
-	return results, nil
+	if a.castableWinCount > 0 {
+		results.averageTurnsToCastableWin = float64(a.castableTurnSum) / float64(a.castableWinCount)
+		results.castableWinCI = wilsonEstimate(a.castableWinCount, n)
+	}
+
+	return results
+}
+
+// z95 is the z-score for a 95% confidence interval.
+const z95 = 1.96
+
+// cltEstimate returns the CLT confidence interval for a sample mean:
+// half-width = z*s/sqrt(n).
+func cltEstimate(mean, stdDev float64, n int64) Estimate {
+	return Estimate{
+		Value:     mean,
+		Center:    mean,
+		HalfWidth: z95 * stdDev / math.Sqrt(float64(n)),
+	}
 }
 
-// createDeck creates a deck with the default setup of lands,
-// non-lands, and combo pieces.
-func createDeck(cfg Config, rng *rand.Rand) []Card {
+// wilsonEstimate returns the Wilson score confidence interval for a
+// proportion of k successes in n trials:
+//
+//	center    = (k + z²/2) / (n + z²)
+//	halfWidth = z·√(k(n-k)/n + z²/4) / (n + z²)
+func wilsonEstimate(k, n int64) Estimate {
+	kf, nf, z2 := float64(k), float64(n), z95*z95
+	return Estimate{
+		Value:     kf / nf,
+		Center:    (kf + z2/2) / (nf + z2),
+		HalfWidth: z95 * math.Sqrt(kf*(nf-kf)/nf+z2/4) / (nf + z2),
+	}
+}
+
+// createDeck creates a deck, either from cfg.decklist if one was loaded,
+// or from the default synthetic setup of lands, non-lands, and combo
+// pieces (all sharing a single "combo" group).
+func createDeck(cfg Config, src Source) []Card {
+	if len(cfg.decklist) > 0 {
+		cards := make([]Card, 0, len(cfg.decklist))
+		for _, dc := range cfg.decklist {
+			cards = append(cards, cardFromDecklist(dc))
+		}
+		return shuffleDeck(cards, src)
+	}
+
 	// setup the distribution of cards for our simulation
 	var numLands = cfg.lands
 	// set the number of non-lands to the rest of the deck
@@ -159,20 +522,19 @@ func createDeck(cfg Config, rng *rand.Rand) []Card {
 	var numComboPieces = cfg.combos
 
 	// create a deck
-	var deck []Card
+	var cards []Card
 
 	// add lands to the deck
 	for i := 0; i < numLands; i++ {
-		deck = append(deck, Card{
+		cards = append(cards, Card{
 			keyword: "land",
 		})
 	}
 
 	// add non-combo permanents
 	for i := 0; i < numNonLands-numComboPieces; i++ {
-		deck = append(deck, Card{
+		cards = append(cards, Card{
 			keyword: "non-land",
-			combo:   false,
 		})
 	}
 
@@ -180,105 +542,395 @@ func createDeck(cfg Config, rng *rand.Rand) []Card {
 	// it is assumed that all combo pieces must be drawn to trigger
 	// the win condition.
 	for i := 0; i < numComboPieces; i++ {
-		deck = append(deck, Card{
-			keyword: "non-land",
-			combo:   true,
+		cards = append(cards, Card{
+			keyword:    "non-land",
+			comboGroup: "combo",
+			manaCost:   cfg.comboManaCost,
 		})
 	}
 
-	return shuffleDeck(deck, rng)
+	return shuffleDeck(cards, src)
 }
 
-// shuffleDeck shuffles a slice of Cards and returns the shuffled slice
-func shuffleDeck(deck []Card, rng *rand.Rand) []Card {
-	rng.Shuffle(len(deck), func(i, j int) {
-		deck[i], deck[j] = deck[j], deck[i]
-	})
-	return deck
+// cardFromDecklist converts a parsed deck.Card into the simulator's
+// internal Card, translating its tags into the fields runSimulation
+// understands.
+func cardFromDecklist(dc deck.Card) Card {
+	keyword := "non-land"
+	for _, t := range dc.Types {
+		if strings.EqualFold(t, "Land") {
+			keyword = "land"
+			break
+		}
+	}
+	return Card{
+		keyword:    keyword,
+		comboGroup: dc.ComboGroup(),
+		tutorGroup: dc.TutorGroup(),
+		ramp:       dc.IsRamp(),
+		drawCount:  dc.DrawCount(),
+		manaCost:   dc.ManaCost,
+	}
+}
+
+// loadDecklist reads a text decklist from path and derives a
+// comboGroups requirement of one piece per distinct combo group found
+// in it, e.g. a deck with combo-piece:A and combo-piece:B tags needs
+// one piece from each to win.
+func loadDecklist(path string) ([]deck.Card, map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	cards, err := deck.Parse(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make(map[string]int)
+	for _, c := range cards {
+		if g := c.ComboGroup(); g != "" {
+			groups[g] = 1
+		}
+	}
+
+	return cards, groups, nil
+}
+
+// shuffleDeck shuffles a slice of Cards in place with a Fisher-Yates
+// pass driven by src, and returns the shuffled slice.
+func shuffleDeck(cards []Card, src Source) []Card {
+	for i := len(cards) - 1; i > 0; i-- {
+		j := int(src.Uint64() % uint64(i+1))
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	return cards
 }
 
-// runSimulation starts drawing down until it hits a win con and
-// then records the results of the simulation for later analysis
-func runSimulation(deck []Card, required int) Simulation {
-	var drawCount int64 = 0
-	hand, deck := deck[:7], deck[7:]
+// runSimulation deals an opening hand (applying the London mulligan rules
+// in cfg.rules), then plays the deck out turn by turn - drawing a card and
+// dropping a land, if one is in hand, each turn - until either the library
+// runs out or the combo is won. It records the raw draw-based win (the
+// required combo pieces simply in hand) as well as the castable win (those
+// same pieces with enough untapped lands in play to pay their combined
+// mana cost).
+func runSimulation(cards []Card, src Source, cfg Config) Simulation {
+	rules := effectiveRules(cfg.rules)
+	requirements := effectiveRequirements(cfg)
+	hand, library, mulligansTaken := drawOpeningHand(cards, src, rules)
 
 	openingLands := 0
-	// check lands in opening hand
 	for _, c := range hand {
 		if c.keyword == "land" {
 			openingLands++
 		}
 	}
 
-	if checkComboWin(hand, required) {
-		return Simulation{
-			drawsToWinCon:    drawCount,
-			openingHandWin:   true,
-			openingHandLands: openingLands,
-		}
+	sim := Simulation{
+		openingHandLands:   openingLands,
+		mulligansTaken:     mulligansTaken,
+		turnsToCastableWin: -1,
 	}
 
-	for len(deck) > 0 {
-		drawCount++
-		// draw
-		drawn := deck[0]
-		deck = deck[1:]
-		hand = append(hand, drawn)
-		// check if enough combo pieces have been hit
-		if checkComboWin(hand, required) {
-			return Simulation{
-				drawsToWinCon:    drawCount,
-				openingHandWin:   false,
-				openingHandLands: openingLands,
+	// resolve any tutor/draw effects already sitting in the opening
+	// hand, in hand order, as if each were cast on turn one.
+	var bonusMana int
+	for _, c := range append([]Card(nil), hand...) {
+		hand, library = resolveEffect(c, hand, library, &bonusMana, 0)
+	}
+
+	var drawCount int64
+	var turn int64
+	landsInPlay := 0
+	rawWin := false
+
+	for {
+		hand, landsInPlay = playLand(hand, landsInPlay)
+
+		if !rawWin && checkComboWin(hand, requirements) {
+			rawWin = true
+			sim.openingHandWin = turn == 0
+			sim.drawsToWinCon = drawCount
+		}
+
+		if rawWin {
+			if cost := castCost(hand, requirements); cost >= 0 && cost <= landsInPlay+bonusMana {
+				sim.castableWin = true
+				sim.turnsToCastableWin = turn
+				break
 			}
 		}
+
+		if len(library) == 0 {
+			break
+		}
+
+		turn++
+		drawCount++
+		hand, library = drawCard(hand, library, &bonusMana, landsInPlay)
+	}
+
+	if !rawWin {
+		sim.drawsToWinCon = drawCount
 	}
 
-	return Simulation{
-		drawsToWinCon:    drawCount,
-		openingHandWin:   false,
-		openingHandLands: openingLands,
+	return sim
+}
+
+// drawCard draws the top card of library into hand and resolves any
+// tutor/draw/ramp effect it grants (which may itself chain into further
+// draws). landsInPlay is the caster's current mana available, needed to
+// gate whether the drawn card's own effect can be afforded.
+func drawCard(hand, library []Card, bonusMana *int, landsInPlay int) ([]Card, []Card) {
+	if len(library) == 0 {
+		return hand, library
 	}
+	card := library[0]
+	library = library[1:]
+	hand = append(hand, card)
+	return resolveEffect(card, hand, library, bonusMana, landsInPlay)
 }
 
-// checks if the required number of combo cards has been drawn
-// into hand for a naive win-con check
-func checkComboWin(hand []Card, required int) bool {
-	var count int = 0
-	for i := 0; i < len(hand); i++ {
-		if hand[i].combo {
-			count++
-			if count == required {
-				return true
+// resolveEffect applies a card's ramp/tutor/draw effect, simplifying
+// "cast this spell" down to an instant hand/library/mana effect -
+// consistent with the sim's existing land-drop simplification. A ramp
+// card is treated as cast the instant it resolves: it leaves hand like
+// a played land, and nets (1 - its own manaCost) bonus mana, so e.g. a
+// free ramp spell nets +1 but one costing 1 or more mana nets zero or
+// less rather than stacking free, permanent mana. A tutor or draw card
+// only resolves if landsInPlay+bonusMana can cover its manaCost, the
+// same castability invariant checkComboWin's cost gate enforces -
+// otherwise it's left in hand, uncast.
+func resolveEffect(card Card, hand, library []Card, bonusMana *int, landsInPlay int) ([]Card, []Card) {
+	if card.ramp {
+		*bonusMana += 1 - card.manaCost
+		hand = removeCard(hand, card)
+	}
+	if card.tutorGroup != "" || card.drawCount > 0 {
+		if landsInPlay+*bonusMana < card.manaCost {
+			return hand, library
+		}
+		*bonusMana -= card.manaCost
+		hand = removeCard(hand, card)
+	}
+	if card.tutorGroup != "" {
+		for i, c := range library {
+			if c.comboGroup == card.tutorGroup {
+				library = append(library[:i], library[i+1:]...)
+				hand = append(hand, c)
+				hand, library = resolveEffect(c, hand, library, bonusMana, landsInPlay)
+				break
 			}
 		}
 	}
-	return false
+	for i := 0; i < card.drawCount; i++ {
+		hand, library = drawCard(hand, library, bonusMana, landsInPlay)
+	}
+	return hand, library
 }
 
-func validateConfig(cfg Config) error {
-	if cfg.deckSize < 7 {
-		return errors.New("deck size must be at least 7")
+// removeCard removes the first occurrence of card from hand by value,
+// the same "find the first match" convention playLand uses for lands.
+func removeCard(hand []Card, card Card) []Card {
+	for i, c := range hand {
+		if c == card {
+			return append(hand[:i], hand[i+1:]...)
+		}
 	}
-	if cfg.lands < 0 {
-		return errors.New("lands cannot be negative")
+	return hand
+}
+
+// playLand moves the first land found in hand onto the battlefield,
+// representing a single land drop for the turn, and returns the updated
+// hand and land count.
+func playLand(hand []Card, landsInPlay int) ([]Card, int) {
+	for i, c := range hand {
+		if c.keyword == "land" {
+			hand = append(hand[:i], hand[i+1:]...)
+			return hand, landsInPlay + 1
+		}
 	}
-	if cfg.combos < 0 {
-		return errors.New("combos cannot be negative")
+	return hand, landsInPlay
+}
+
+// castCost returns the combined mana cost of satisfying every group in
+// requirements from hand, or -1 if hand doesn't yet have enough pieces
+// of some group.
+func castCost(hand []Card, requirements map[string]int) int {
+	remaining := make(map[string]int, len(requirements))
+	for group, need := range requirements {
+		remaining[group] = need
 	}
-	if cfg.required < 1 {
-		return errors.New("required combo pieces must be at least 1")
+
+	cost := 0
+	for _, c := range hand {
+		if c.comboGroup == "" || remaining[c.comboGroup] <= 0 {
+			continue
+		}
+		cost += c.manaCost
+		remaining[c.comboGroup]--
 	}
-	if cfg.required > cfg.combos {
-		return fmt.Errorf("required combo pieces (%d) cannot exceed total combo pieces (%d)", cfg.required, cfg.combos)
+
+	for _, need := range remaining {
+		if need > 0 {
+			return -1
+		}
+	}
+	return cost
+}
+
+// effectiveRequirements returns the combo groups and counts needed for
+// a win: cfg.comboGroups if set (from a decklist), or a single "combo"
+// group of cfg.required pieces otherwise, matching the original,
+// anonymous-combo-pool sim.
+func effectiveRequirements(cfg Config) map[string]int {
+	if len(cfg.comboGroups) > 0 {
+		return cfg.comboGroups
+	}
+	return map[string]int{"combo": cfg.required}
+}
+
+// effectiveDeckSize returns the number of cards createDeck will actually
+// build: the decklist's length if one was loaded, or cfg.deckSize.
+func effectiveDeckSize(cfg Config) int {
+	if len(cfg.decklist) > 0 {
+		return len(cfg.decklist)
 	}
-	if cfg.lands+cfg.combos > cfg.deckSize {
-		return fmt.Errorf("lands (%d) + combos (%d) cannot exceed deck size (%d)", cfg.lands, cfg.combos, cfg.deckSize)
+	return cfg.deckSize
+}
+
+// effectiveRules fills in the zero-value defaults for an unset Rules,
+// mirroring the seed-0-means-current-time convention used elsewhere for
+// Config: a zero-value Rules plays like the original, mulligan-free sim.
+func effectiveRules(r Rules) Rules {
+	if r.startingHandSize == 0 {
+		r.startingHandSize = 7
+	}
+	return r
+}
+
+// drawOpeningHand shuffles the full deck and draws a starting hand,
+// taking London mulligans per rules until a hand is kept or the mulligan
+// cap is reached: each mulligan reshuffles the whole deck and draws a
+// fresh startingHandSize hand. Once a hand is kept, bottomPerMulligan
+// cards per mulligan taken are put on the bottom of the library.
+func drawOpeningHand(cards []Card, src Source, rules Rules) (hand []Card, library []Card, mulligansTaken int) {
+	full := make([]Card, len(cards))
+	copy(full, cards)
+
+	for {
+		shuffled := shuffleDeck(full, src)
+		hand = append([]Card(nil), shuffled[:rules.startingHandSize]...)
+		library = append([]Card(nil), shuffled[rules.startingHandSize:]...)
+
+		if mulligansTaken >= rules.maxMulligans || keepHand(hand) {
+			break
+		}
+		mulligansTaken++
+	}
+
+	bottom := mulligansTaken * rules.bottomPerMulligan
+	if bottom > len(hand) {
+		bottom = len(hand)
+	}
+	if bottom > 0 {
+		library = append(library, hand[len(hand)-bottom:]...)
+		hand = hand[:len(hand)-bottom]
+	}
+
+	return hand, library, mulligansTaken
+}
+
+// keepHand applies a simple flood/screw heuristic: a hand with too few or
+// too many lands is mulliganed away.
+func keepHand(hand []Card) bool {
+	lands := 0
+	for _, c := range hand {
+		if c.keyword == "land" {
+			lands++
+		}
+	}
+	return lands >= 2 && lands <= 5
+}
+
+// checkComboWin reports whether hand satisfies every combo group
+// requirement, e.g. one piece from group A and one from group B.
+func checkComboWin(hand []Card, requirements map[string]int) bool {
+	counts := make(map[string]int, len(requirements))
+	for _, c := range hand {
+		if c.comboGroup != "" {
+			counts[c.comboGroup]++
+		}
+	}
+	for group, need := range requirements {
+		if counts[group] < need {
+			return false
+		}
+	}
+	return true
+}
+
+func validateConfig(cfg Config) error {
+	if len(cfg.decklist) == 0 {
+		if cfg.deckSize < 7 {
+			return errors.New("deck size must be at least 7")
+		}
+		if cfg.lands < 0 {
+			return errors.New("lands cannot be negative")
+		}
+		if cfg.combos < 0 {
+			return errors.New("combos cannot be negative")
+		}
+		if len(cfg.comboGroups) == 0 {
+			if cfg.required < 1 {
+				return errors.New("required combo pieces must be at least 1")
+			}
+			if cfg.required > cfg.combos {
+				return fmt.Errorf("required combo pieces (%d) cannot exceed total combo pieces (%d)", cfg.required, cfg.combos)
+			}
+		}
+		if cfg.lands+cfg.combos > cfg.deckSize {
+			return fmt.Errorf("lands (%d) + combos (%d) cannot exceed deck size (%d)", cfg.lands, cfg.combos, cfg.deckSize)
+		}
 	}
 	if cfg.runs < 1 {
 		return errors.New("runs must be at least 1")
 	}
+	if cfg.rules.startingHandSize < 0 {
+		return errors.New("starting hand size cannot be negative")
+	}
+	if startingHandSize := effectiveRules(cfg.rules).startingHandSize; startingHandSize > effectiveDeckSize(cfg) {
+		return fmt.Errorf("starting hand size (%d) cannot exceed deck size (%d)", startingHandSize, effectiveDeckSize(cfg))
+	}
+	if cfg.rules.maxMulligans < 0 {
+		return errors.New("max mulligans cannot be negative")
+	}
+	if cfg.rules.bottomPerMulligan < 0 {
+		return errors.New("bottom per mulligan cannot be negative")
+	}
+	if cfg.comboManaCost < 0 {
+		return errors.New("combo mana cost cannot be negative")
+	}
+	if cfg.adaptive {
+		if cfg.epsilon <= 0 {
+			return errors.New("epsilon must be positive in adaptive mode")
+		}
+		if cfg.batchSize < 1 {
+			return errors.New("batch size must be at least 1 in adaptive mode")
+		}
+	}
+	switch cfg.mode {
+	case "", "monte-carlo", "analytic", "sweep":
+	default:
+		return fmt.Errorf("unknown mode %q: must be monte-carlo, analytic, or sweep", cfg.mode)
+	}
+	switch cfg.rngKind {
+	case "", "pcg", "math-rand":
+	default:
+		return fmt.Errorf("unknown rng %q: must be pcg or math-rand", cfg.rngKind)
+	}
 	return nil
 }
 
@@ -2,7 +2,10 @@ package main
 
 import (
 	"math/rand"
+	"strings"
 	"testing"
+
+	"github.com/dylanlott/mindcrank/deck"
 )
 
 func TestRunScenarioDeterministicWithSeed(t *testing.T) {
@@ -43,6 +46,289 @@ func TestValidateConfigRejectsRequiredZero(t *testing.T) {
 	}
 }
 
+func TestValidateConfigRejectsStartingHandSizeExceedingDeckSize(t *testing.T) {
+	cfg := Config{
+		deckSize: 60,
+		lands:    24,
+		combos:   4,
+		required: 2,
+		runs:     100,
+		seed:     1,
+		rules:    Rules{startingHandSize: 100},
+	}
+	if err := validateConfig(cfg); err == nil {
+		t.Fatal("expected starting hand size larger than deck size to be rejected")
+	}
+}
+
+func TestRunSimulationCastableWinRequiresMana(t *testing.T) {
+	cfg := Config{
+		deckSize:      10,
+		lands:         0,
+		combos:        2,
+		required:      2,
+		comboManaCost: 3,
+		rules:         Rules{startingHandSize: 2},
+	}
+	// hand is both combo pieces and nothing else; no lands ever enter
+	// play, so the combo can never be paid for.
+	deck := []Card{
+		{keyword: "non-land", comboGroup: "combo", manaCost: 3},
+		{keyword: "non-land", comboGroup: "combo", manaCost: 3},
+		{keyword: "non-land"},
+		{keyword: "non-land"},
+		{keyword: "non-land"},
+		{keyword: "non-land"},
+		{keyword: "non-land"},
+		{keyword: "non-land"},
+	}
+	rng := rand.New(rand.NewSource(1))
+
+	sim := runSimulation(deck, rng, cfg)
+
+	if sim.castableWin {
+		t.Fatalf("expected combo to be uncastable with zero lands: %+v", sim)
+	}
+	if sim.turnsToCastableWin != -1 {
+		t.Fatalf("expected turnsToCastableWin=-1, got %d", sim.turnsToCastableWin)
+	}
+}
+
+func TestResolveEffectRampChargesOwnManaCost(t *testing.T) {
+	card := Card{keyword: "non-land", ramp: true, manaCost: 1}
+	hand := []Card{card, {keyword: "land"}}
+	var bonusMana int
+
+	hand, _ = resolveEffect(card, hand, nil, &bonusMana, 0)
+
+	if bonusMana != 0 {
+		t.Fatalf("expected a manaCost=1 ramp card to net 1-1=0 bonus mana, got %d", bonusMana)
+	}
+	for _, c := range hand {
+		if c == card {
+			t.Fatalf("expected the cast ramp card to be removed from hand, got %+v", hand)
+		}
+	}
+}
+
+func TestResolveEffectFreeRampNetsOneMana(t *testing.T) {
+	card := Card{keyword: "non-land", ramp: true, manaCost: 0}
+	hand := []Card{card}
+	var bonusMana int
+
+	resolveEffect(card, hand, nil, &bonusMana, 0)
+
+	if bonusMana != 1 {
+		t.Fatalf("expected a free ramp card to net +1 bonus mana, got %d", bonusMana)
+	}
+}
+
+func TestResolveEffectTutorFindsCardWhenAffordable(t *testing.T) {
+	tutor := Card{keyword: "non-land", tutorGroup: "combo", manaCost: 2}
+	piece := Card{keyword: "non-land", comboGroup: "combo"}
+	hand := []Card{tutor}
+	library := []Card{{keyword: "land"}, piece}
+	bonusMana := 2
+
+	hand, library = resolveEffect(tutor, hand, library, &bonusMana, 0)
+
+	if bonusMana != 0 {
+		t.Fatalf("expected the tutor's manaCost=2 to be deducted from bonusMana, got %d", bonusMana)
+	}
+	for _, c := range hand {
+		if c == tutor {
+			t.Fatalf("expected the cast tutor to be removed from hand, got %+v", hand)
+		}
+	}
+	found := false
+	for _, c := range hand {
+		if c == piece {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the tutored combo piece to move from library to hand, got hand=%+v library=%+v", hand, library)
+	}
+	for _, c := range library {
+		if c == piece {
+			t.Fatalf("expected the tutored combo piece to be removed from library, got %+v", library)
+		}
+	}
+}
+
+func TestResolveEffectTutorDoesNotResolveWhenUnaffordable(t *testing.T) {
+	tutor := Card{keyword: "non-land", tutorGroup: "combo", manaCost: 2}
+	piece := Card{keyword: "non-land", comboGroup: "combo"}
+	hand := []Card{tutor}
+	library := []Card{piece}
+	var bonusMana int
+
+	hand, library = resolveEffect(tutor, hand, library, &bonusMana, 0)
+
+	if bonusMana != 0 {
+		t.Fatalf("expected no mana to be spent when the tutor can't be cast, got %d", bonusMana)
+	}
+	found := false
+	for _, c := range hand {
+		if c == tutor {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the uncastable tutor to remain in hand, got %+v", hand)
+	}
+	for _, c := range library {
+		if c == piece {
+			return
+		}
+	}
+	t.Fatalf("expected the combo piece to remain in library, unsearched, got %+v", library)
+}
+
+func TestDrawOpeningHandTakesMulligansUntilKept(t *testing.T) {
+	// a deck that is all lands can never satisfy keepHand's 2-5 land
+	// window with a 7-card hand, so every mulligan should be taken.
+	deck := make([]Card, 60)
+	for i := range deck {
+		deck[i] = Card{keyword: "land"}
+	}
+	rules := Rules{startingHandSize: 7, maxMulligans: 3, bottomPerMulligan: 1}
+	rng := rand.New(rand.NewSource(7))
+
+	hand, library, mulligansTaken := drawOpeningHand(deck, rng, rules)
+
+	if mulligansTaken != rules.maxMulligans {
+		t.Fatalf("expected all %d mulligans to be taken, got %d", rules.maxMulligans, mulligansTaken)
+	}
+	wantHandSize := rules.startingHandSize - mulligansTaken*rules.bottomPerMulligan
+	if len(hand) != wantHandSize {
+		t.Fatalf("hand size mismatch: got=%d want=%d", len(hand), wantHandSize)
+	}
+	if len(hand)+len(library) != len(deck) {
+		t.Fatalf("cards lost during mulligans: hand=%d library=%d deck=%d", len(hand), len(library), len(deck))
+	}
+}
+
+func TestRunSimulationRequiresOnePieceFromEachComboGroup(t *testing.T) {
+	list := `1 Tainted Pact {1}{U} combo-piece:A
+1 Lion's Eye Diamond combo-piece:B
+6 Island {U} type:Land
+`
+	cards, err := deck.Parse(strings.NewReader(list))
+	if err != nil {
+		t.Fatalf("deck.Parse returned error: %v", err)
+	}
+
+	cfg := Config{
+		runs:        1,
+		seed:        1,
+		decklist:    cards,
+		comboGroups: map[string]int{"A": 1, "B": 1},
+		rules:       Rules{startingHandSize: 8},
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	deckCards := createDeck(cfg, rng)
+
+	sim := runSimulation(deckCards, rng, cfg)
+
+	if !sim.openingHandWin {
+		t.Fatalf("expected both combo pieces to be drawn with an 8-card hand of an 8-card deck: %+v", sim)
+	}
+}
+
+func TestAggregatorAddBatchFoldsOutOfOrderArrivalsInFixedOrder(t *testing.T) {
+	sims := []Simulation{
+		{simIndex: 0, drawsToWinCon: 3},
+		{simIndex: 1, drawsToWinCon: 7},
+		{simIndex: 2, drawsToWinCon: 1},
+		{simIndex: 3, drawsToWinCon: 9},
+	}
+
+	inOrder := &aggregator{}
+	batch := make(chan Simulation, len(sims))
+	for _, sim := range sims {
+		batch <- sim
+	}
+	close(batch)
+	inOrder.addBatch(batch)
+
+	outOfOrder := &aggregator{}
+	shuffled := make(chan Simulation, len(sims))
+	for _, i := range []int{2, 0, 3, 1} {
+		shuffled <- sims[i]
+	}
+	close(shuffled)
+	outOfOrder.addBatch(shuffled)
+
+	if got, want := outOfOrder.results(), inOrder.results(); got != want {
+		t.Fatalf("out-of-order arrival changed the result: got=%+v want=%+v", got, want)
+	}
+}
+
+func TestWilsonEstimateHalfWidthShrinksWithMoreTrials(t *testing.T) {
+	small := wilsonEstimate(25, 100)
+	large := wilsonEstimate(2500, 10000)
+
+	if small.Value != 0.25 || large.Value != 0.25 {
+		t.Fatalf("expected both estimates to have Value=0.25, got %v and %v", small.Value, large.Value)
+	}
+	if large.HalfWidth >= small.HalfWidth {
+		t.Fatalf("expected a 10000-trial CI to be tighter than a 100-trial CI: got=%v want<%v", large.HalfWidth, small.HalfWidth)
+	}
+}
+
+func TestRunAdaptiveScenarioStopsBeforeMaxRuns(t *testing.T) {
+	cfg := Config{
+		deckSize:  99,
+		lands:     37,
+		combos:    4,
+		required:  2,
+		runs:      1_000_000,
+		seed:      7,
+		adaptive:  true,
+		epsilon:   0.02,
+		batchSize: 2000,
+	}
+
+	results, err := runAdaptiveScenario(cfg)
+	if err != nil {
+		t.Fatalf("runAdaptiveScenario failed: %v", err)
+	}
+	if results.attempts >= int64(cfg.runs) {
+		t.Fatalf("expected adaptive mode to stop well before the %d-run cap, ran %d", cfg.runs, results.attempts)
+	}
+	if results.openingHandWinCI.HalfWidth > cfg.epsilon {
+		t.Fatalf("expected final half-width <= epsilon: got=%v want<=%v", results.openingHandWinCI.HalfWidth, cfg.epsilon)
+	}
+}
+
+func TestAnalyticWinProbabilitiesMatchesMonteCarloWithinCI(t *testing.T) {
+	cfg := Config{
+		deckSize: 40,
+		lands:    17,
+		combos:   4,
+		required: 2,
+		runs:     200_000,
+		seed:     99,
+		rules:    Rules{startingHandSize: 7},
+	}
+
+	got, err := runScenario(cfg)
+	if err != nil {
+		t.Fatalf("runScenario failed: %v", err)
+	}
+
+	rows := AnalyticWinProbabilities(cfg)
+	exact := rows[0].ProbWin // turn 0 is the opening hand, same question openingHandWinCI answers
+
+	ci := got.openingHandWinCI
+	lo, hi := ci.Center-ci.HalfWidth, ci.Center+ci.HalfWidth
+	if exact < lo || exact > hi {
+		t.Fatalf("exact analytic P(win)=%.6f outside Monte Carlo CI [%.6f, %.6f]", exact, lo, hi)
+	}
+}
+
 func TestCreateDeckUsesDeckSize(t *testing.T) {
 	cfg := Config{
 		deckSize: 60,
@@ -65,7 +351,7 @@ func TestCreateDeckUsesDeckSize(t *testing.T) {
 		if c.keyword == "land" {
 			landCount++
 		}
-		if c.combo {
+		if c.comboGroup != "" {
 			comboCount++
 		}
 	}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPCGSourceIsDeterministicWithSeed(t *testing.T) {
+	a := newPCGSource(42)
+	b := newPCGSource(42)
+
+	for i := 0; i < 100; i++ {
+		if got, want := a.Uint64(), b.Uint64(); got != want {
+			t.Fatalf("draw %d: got=%d want=%d", i, got, want)
+		}
+	}
+}
+
+func TestPCGSourceDiffersAcrossSeeds(t *testing.T) {
+	a := newPCGSource(1)
+	b := newPCGSource(2)
+
+	same := true
+	for i := 0; i < 8; i++ {
+		if a.Uint64() != b.Uint64() {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Fatal("expected distinct seeds to diverge within 8 draws")
+	}
+}
+
+func TestShuffleDeckProducesAPermutation(t *testing.T) {
+	cards := make([]Card, 60)
+	for i := range cards {
+		cards[i] = Card{keyword: "land", manaCost: i}
+	}
+
+	shuffleDeck(cards, newPCGSource(7))
+
+	seen := make(map[int]bool, len(cards))
+	for _, c := range cards {
+		seen[c.manaCost] = true
+	}
+	if len(seen) != len(cards) {
+		t.Fatalf("shuffle lost or duplicated cards: got %d distinct of %d", len(seen), len(cards))
+	}
+}
+
+func TestNewSourceSelectsMathRandByName(t *testing.T) {
+	if _, ok := newSource("math-rand", 1).(*rand.Rand); !ok {
+		t.Fatal(`expected newSource("math-rand", ...) to return a *rand.Rand`)
+	}
+	if _, ok := newSource("pcg", 1).(*pcgSource); !ok {
+		t.Fatal(`expected newSource("pcg", ...) to return a *pcgSource`)
+	}
+	if _, ok := newSource("", 1).(*pcgSource); !ok {
+		t.Fatal(`expected newSource("", ...) to default to a *pcgSource`)
+	}
+}
+
+func benchmarkShuffle(b *testing.B, src Source, deckSize int) {
+	cards := make([]Card, deckSize)
+	for i := range cards {
+		cards[i] = Card{keyword: "non-land"}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		shuffleDeck(cards, src)
+	}
+}
+
+func BenchmarkShuffleMathRand60(b *testing.B) {
+	benchmarkShuffle(b, rand.New(rand.NewSource(1)), 60)
+}
+
+func BenchmarkShuffleMathRand99(b *testing.B) {
+	benchmarkShuffle(b, rand.New(rand.NewSource(1)), 99)
+}
+
+func BenchmarkShuffleMathRand250(b *testing.B) {
+	benchmarkShuffle(b, rand.New(rand.NewSource(1)), 250)
+}
+
+func BenchmarkShufflePCG60(b *testing.B) {
+	benchmarkShuffle(b, newPCGSource(1), 60)
+}
+
+func BenchmarkShufflePCG99(b *testing.B) {
+	benchmarkShuffle(b, newPCGSource(1), 99)
+}
+
+func BenchmarkShufflePCG250(b *testing.B) {
+	benchmarkShuffle(b, newPCGSource(1), 250)
+}
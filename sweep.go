@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SweepPoint is one combination from a sweep's Cartesian product of
+// swept parameters, together with the Results runScenario produced for
+// it.
+type SweepPoint struct {
+	Lands        int     `json:"lands"`
+	Combos       int     `json:"combos"`
+	Required     int     `json:"required"`
+	MaxMulligans int     `json:"max_mulligans"`
+	Results      Results `json:"results"`
+}
+
+// parseRange parses a "start:end[:step]" range spec (step optional,
+// default 1) into the inclusive list of ints it describes. An empty
+// spec returns nil, meaning "don't sweep this axis" to RunSweep.
+func parseRange(spec string) ([]int, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid range %q: want start:end[:step]", spec)
+	}
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", spec, err)
+	}
+	end, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", spec, err)
+	}
+	step := 1
+	if len(parts) == 3 {
+		if step, err = strconv.Atoi(parts[2]); err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", spec, err)
+		}
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("invalid range %q: step must be positive", spec)
+	}
+
+	var values []int
+	for v := start; v <= end; v += step {
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// RunSweep runs base once per point in the Cartesian product of
+// landsRange x combosRange x requiredRange x maxMulligansRange, in
+// parallel across a worker pool, and returns one SweepPoint per
+// combination. Any range left empty sweeps only base's own value for
+// that axis.
+func RunSweep(base Config, landsRange, combosRange, requiredRange, maxMulligansRange []int) ([]SweepPoint, error) {
+	if len(landsRange) == 0 {
+		landsRange = []int{base.lands}
+	}
+	if len(combosRange) == 0 {
+		combosRange = []int{base.combos}
+	}
+	if len(requiredRange) == 0 {
+		requiredRange = []int{base.required}
+	}
+	if len(maxMulligansRange) == 0 {
+		maxMulligansRange = []int{base.rules.maxMulligans}
+	}
+
+	var points []SweepPoint
+	for _, lands := range landsRange {
+		for _, combos := range combosRange {
+			for _, required := range requiredRange {
+				for _, maxMulligans := range maxMulligansRange {
+					points = append(points, SweepPoint{
+						Lands:        lands,
+						Combos:       combos,
+						Required:     required,
+						MaxMulligans: maxMulligans,
+					})
+				}
+			}
+		}
+	}
+
+	type outcome struct {
+		index int
+		res   Results
+		err   error
+	}
+
+	jobs := make(chan int, len(points))
+	out := make(chan outcome, len(points))
+
+	workerCount := runtime.NumCPU()
+	if workerCount > len(points) {
+		workerCount = len(points)
+	}
+
+	workers := &sync.WaitGroup{}
+	workers.Add(workerCount)
+	for w := 0; w < workerCount; w++ {
+		go func() {
+			defer workers.Done()
+			for i := range jobs {
+				p := points[i]
+				cfg := base
+				cfg.lands = p.Lands
+				cfg.combos = p.Combos
+				cfg.required = p.Required
+				cfg.rules.maxMulligans = p.MaxMulligans
+
+				if err := validateConfig(cfg); err != nil {
+					out <- outcome{index: i, err: fmt.Errorf("sweep point lands=%d combos=%d required=%d max-mulligans=%d: %w", p.Lands, p.Combos, p.Required, p.MaxMulligans, err)}
+					continue
+				}
+				res, err := runScenario(cfg)
+				out <- outcome{index: i, res: res, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range points {
+			jobs <- i
+		}
+		close(jobs)
+		workers.Wait()
+		close(out)
+	}()
+
+	for o := range out {
+		if o.err != nil {
+			return nil, o.err
+		}
+		points[o.index].Results = o.res
+	}
+
+	return points, nil
+}
+
+// sweepMetrics flattens a Results into a stable, ordered list of
+// (name, value) pairs, shared by WriteSweepCSV's wide header and its
+// long-format rows so the two never drift apart.
+func sweepMetrics(r Results) []struct {
+	name  string
+	value float64
+} {
+	return []struct {
+		name  string
+		value float64
+	}{
+		{"attempts", float64(r.attempts)},
+		{"average_draws_to_win", r.averageDrawsToWin},
+		{"opening_hand_wins", float64(r.openingHandWins)},
+		{"average_opening_hand_win_rate", r.averageOpeningHandWins},
+		{"opening_hand_win_ci_half_width", r.openingHandWinCI.HalfWidth},
+		{"average_opening_lands", r.averageOpeningLands},
+		{"average_mulligans", r.averageMulligans},
+		{"castable_wins", float64(r.castableWins)},
+		{"average_castable_win_rate", r.averageCastableWinRate},
+		{"castable_win_ci_half_width", r.castableWinCI.HalfWidth},
+		{"average_turns_to_castable_win", r.averageTurnsToCastableWin},
+	}
+}
+
+// WriteSweepCSV writes points as CSV to w. format selects "wide" (one
+// row per point, one column per metric; the default) or "long" (one row
+// per point/metric pair), for pivoting in pandas/R.
+func WriteSweepCSV(w io.Writer, points []SweepPoint, format string) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	if format == "long" {
+		if err := cw.Write([]string{"lands", "combos", "required", "max_mulligans", "metric", "value"}); err != nil {
+			return err
+		}
+		for _, p := range points {
+			prefix := []string{
+				strconv.Itoa(p.Lands), strconv.Itoa(p.Combos),
+				strconv.Itoa(p.Required), strconv.Itoa(p.MaxMulligans),
+			}
+			for _, m := range sweepMetrics(p.Results) {
+				row := append(append([]string{}, prefix...), m.name, strconv.FormatFloat(m.value, 'f', -1, 64))
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+		return cw.Error()
+	}
+
+	header := []string{"lands", "combos", "required", "max_mulligans"}
+	if len(points) > 0 {
+		for _, m := range sweepMetrics(points[0].Results) {
+			header = append(header, m.name)
+		}
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, p := range points {
+		row := []string{
+			strconv.Itoa(p.Lands), strconv.Itoa(p.Combos),
+			strconv.Itoa(p.Required), strconv.Itoa(p.MaxMulligans),
+		}
+		for _, m := range sweepMetrics(p.Results) {
+			row = append(row, strconv.FormatFloat(m.value, 'f', -1, 64))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	return cw.Error()
+}
+
+// WriteSweepJSON writes points as a JSON array to w, one object per
+// point with its swept parameters alongside its Results.
+func WriteSweepJSON(w io.Writer, points []SweepPoint) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(points)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseRangeExpandsStep(t *testing.T) {
+	got, err := parseRange("10:20:5")
+	if err != nil {
+		t.Fatalf("parseRange returned error: %v", err)
+	}
+	want := []int{10, 15, 20}
+	if len(got) != len(want) {
+		t.Fatalf("got=%v want=%v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got=%v want=%v", got, want)
+		}
+	}
+}
+
+func TestParseRangeDefaultsStepToOne(t *testing.T) {
+	got, err := parseRange("1:3")
+	if err != nil {
+		t.Fatalf("parseRange returned error: %v", err)
+	}
+	want := []int{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got=%v want=%v", got, want)
+	}
+}
+
+func TestParseRangeEmptyReturnsNil(t *testing.T) {
+	got, err := parseRange("")
+	if err != nil {
+		t.Fatalf("parseRange returned error: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil for empty spec, got %v", got)
+	}
+}
+
+func TestParseRangeRejectsMalformedSpec(t *testing.T) {
+	for _, spec := range []string{"10", "10:20:5:1", "a:20", "10:20:0"} {
+		if _, err := parseRange(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestRunSweepCoversCartesianProduct(t *testing.T) {
+	base := Config{
+		deckSize: 40,
+		lands:    17,
+		combos:   4,
+		required: 2,
+		runs:     200,
+		seed:     1,
+	}
+
+	points, err := RunSweep(base, []int{15, 17}, nil, []int{1, 2}, nil)
+	if err != nil {
+		t.Fatalf("RunSweep returned error: %v", err)
+	}
+	if len(points) != 4 {
+		t.Fatalf("expected 2x1x2x1=4 points, got %d", len(points))
+	}
+	for _, p := range points {
+		if p.Results.attempts != int64(base.runs) {
+			t.Fatalf("expected every point to have run %d attempts, got %d", base.runs, p.Results.attempts)
+		}
+	}
+}
+
+func TestRunSweepRejectsInvalidPoint(t *testing.T) {
+	base := Config{
+		deckSize: 40,
+		lands:    17,
+		combos:   4,
+		required: 2,
+		runs:     10,
+		seed:     1,
+	}
+
+	if _, err := RunSweep(base, nil, nil, []int{0}, nil); err == nil {
+		t.Fatal("expected required=0 sweep point to be rejected")
+	}
+}
+
+func TestWriteSweepCSVWideHasOneRowPerPoint(t *testing.T) {
+	points := []SweepPoint{
+		{Lands: 15, Combos: 4, Required: 2, Results: Results{attempts: 1}},
+		{Lands: 17, Combos: 4, Required: 2, Results: Results{attempts: 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSweepCSV(&buf, points, "wide"); err != nil {
+		t.Fatalf("WriteSweepCSV returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 { // header + 2 points
+		t.Fatalf("expected 3 lines (header + 2 rows), got %d: %q", len(lines), buf.String())
+	}
+}
+
+func TestWriteSweepCSVLongHasOneRowPerMetric(t *testing.T) {
+	points := []SweepPoint{
+		{Lands: 15, Combos: 4, Required: 2, Results: Results{attempts: 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSweepCSV(&buf, points, "long"); err != nil {
+		t.Fatalf("WriteSweepCSV returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	wantLines := 1 + len(sweepMetrics(points[0].Results)) // header + one row per metric
+	if len(lines) != wantLines {
+		t.Fatalf("expected %d lines, got %d: %q", wantLines, len(lines), buf.String())
+	}
+}
+
+func TestWriteSweepJSONRoundTrips(t *testing.T) {
+	points := []SweepPoint{
+		{Lands: 15, Combos: 4, Required: 2, Results: Results{attempts: 42}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSweepJSON(&buf, points); err != nil {
+		t.Fatalf("WriteSweepJSON returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"attempts": 42`) {
+		t.Fatalf("expected marshaled attempts in output: %s", buf.String())
+	}
+}